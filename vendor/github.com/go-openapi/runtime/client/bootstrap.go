@@ -0,0 +1,353 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bootstrapClaims are the fields this package understands from a bootstrap
+// token. Unknown claims are ignored.
+type bootstrapClaims struct {
+	CAURL         string   `json:"ca_url"`
+	CAFingerprint string   `json:"ca_fingerprint"`
+	Subject       string   `json:"sub"`
+	SANs          []string `json:"sans"`
+}
+
+// parseBootstrapToken extracts the claims from the payload segment of a JWT
+// without verifying its signature: the token is presented as bearer auth to
+// the CA, which is the party that actually authenticates it. The claims are
+// only used here to discover where to fetch the root from and what identity
+// to request.
+func parseBootstrapToken(token string) (bootstrapClaims, error) {
+	var claims bootstrapClaims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, fmt.Errorf("bootstrap token: malformed JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, fmt.Errorf("bootstrap token: decode claims: %v", err)
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, fmt.Errorf("bootstrap token: parse claims: %v", err)
+	}
+	if claims.CAURL == "" || claims.CAFingerprint == "" {
+		return claims, fmt.Errorf("bootstrap token: missing ca_url or ca_fingerprint claim")
+	}
+	return claims, nil
+}
+
+// bootstrapIdentity is the mTLS identity obtained via the bootstrap flow. It
+// is swapped in place by the renewal goroutine and served to the transport
+// through GetClientCertificate, following the same pattern as certReloader.
+type bootstrapIdentity struct {
+	mu   sync.RWMutex
+	cert tls.Certificate
+	leaf *x509.Certificate
+}
+
+func (b *bootstrapIdentity) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	cert := b.cert
+	return &cert, nil
+}
+
+func (b *bootstrapIdentity) set(cert tls.Certificate, leaf *x509.Certificate) {
+	b.mu.Lock()
+	b.cert = cert
+	b.leaf = leaf
+	b.mu.Unlock()
+}
+
+func (b *bootstrapIdentity) Leaf() *x509.Certificate {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.leaf
+}
+
+// NewFromBootstrapToken builds a Runtime whose mTLS identity is obtained at
+// startup from an internal CA, rather than from PEM files on disk. token is
+// a JWT carrying the CA's URL, the SHA-256 fingerprint of its root (used to
+// pin the initial fetch), and the subject/SANs to request.
+//
+// The returned Runtime renews its identity in the background once two
+// thirds of the current certificate's lifetime has elapsed; call
+// StopRenewal to halt that goroutine.
+func NewFromBootstrapToken(ctx context.Context, token, host, basePath string, schemes []string) (*Runtime, error) {
+	claims, err := parseBootstrapToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	caCert, err := fetchPinnedCA(ctx, claims.CAURL, claims.CAFingerprint)
+	if err != nil {
+		return nil, err
+	}
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: generate key: %v", err)
+	}
+	csrDER, err := buildCSR(key, claims.Subject, claims.SANs)
+	if err != nil {
+		return nil, err
+	}
+
+	bearerClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: caPool}}}
+	chain, err := signCSR(ctx, bearerClient, claims.CAURL, csrDER, token)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: parse issued certificate: %v", err)
+	}
+
+	identity := &bootstrapIdentity{}
+	identity.set(tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: leaf}, leaf)
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:              caPool,
+			GetClientCertificate: identity.GetClientCertificate,
+		},
+	}
+
+	rt, err := NewWithClient(host, basePath, schemes, &http.Client{Transport: transport})
+	if err != nil {
+		return nil, err
+	}
+	rt.bootstrap = identity
+
+	renewalCtx, cancel := context.WithCancel(ctx)
+	rt.renewalCancel = cancel
+	go runRenewal(renewalCtx, identity, claims.CAURL, claims.Subject, claims.SANs, caPool)
+
+	return rt, nil
+}
+
+// StopRenewal halts the background renewal goroutine started by
+// NewFromBootstrapToken. It is a no-op on a Runtime that was not created
+// that way.
+func (r *Runtime) StopRenewal() {
+	if r.renewalCancel != nil {
+		r.renewalCancel()
+	}
+}
+
+// LeafCertificate returns the client certificate currently in use by a
+// Runtime created with NewFromBootstrapToken, or nil otherwise.
+func (r *Runtime) LeafCertificate() *x509.Certificate {
+	if r.bootstrap == nil {
+		return nil
+	}
+	return r.bootstrap.Leaf()
+}
+
+// renewalBackoffPolicy bounds how hard runRenewal hammers the CA when
+// renewal keeps failing (e.g. the CA is unreachable): the same
+// exponential-backoff-with-full-jitter shape RetryPolicy uses for Submit.
+var renewalBackoffPolicy = RetryPolicy{MinBackoff: time.Second, MaxBackoff: 2 * time.Minute}
+
+// runRenewal re-signs the bootstrap identity once 2/3 of its lifetime has
+// elapsed, using the current certificate to authenticate to the CA via mTLS.
+// A failed renewal attempt is retried with capped exponential backoff rather
+// than immediately, so a CA outage doesn't turn into a retry storm.
+func runRenewal(ctx context.Context, identity *bootstrapIdentity, caURL, subject string, sans []string, caPool *x509.CertPool) {
+	failures := 0
+	for {
+		wait := time.Duration(0)
+		if failures > 0 {
+			wait = renewalBackoffPolicy.backoff(failures - 1)
+		} else {
+			leaf := identity.Leaf()
+			lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+			renewAt := leaf.NotBefore.Add(lifetime * 2 / 3)
+			if until := time.Until(renewAt); until > 0 {
+				wait = until
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if err := renewOnce(ctx, identity, caURL, subject, sans, caPool); err != nil {
+			failures++
+			continue
+		}
+		failures = 0
+	}
+}
+
+// renewOnce requests and installs a fresh certificate for identity.
+func renewOnce(ctx context.Context, identity *bootstrapIdentity, caURL, subject string, sans []string, caPool *x509.CertPool) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csrDER, err := buildCSR(key, subject, sans)
+	if err != nil {
+		return err
+	}
+
+	mtlsClient := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:              caPool,
+			GetClientCertificate: identity.GetClientCertificate,
+		},
+	}}
+	chain, err := signCSR(ctx, mtlsClient, caURL, csrDER, "")
+	if err != nil {
+		// Keep using the current identity; the caller backs off and retries.
+		return err
+	}
+	newLeaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return err
+	}
+	identity.set(tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: newLeaf}, newLeaf)
+	return nil
+}
+
+// fetchPinnedCA fetches a PEM-encoded root certificate from caURL, accepting
+// the TLS connection only if one of the certificates the server presents
+// matches the given hex-encoded SHA-256 fingerprint.
+func fetchPinnedCA(ctx context.Context, caURL, fingerprint string) (*x509.Certificate, error) {
+	want, err := hex.DecodeString(fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: decode ca_fingerprint: %v", err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				for _, raw := range rawCerts {
+					sum := sha256.Sum256(raw)
+					if bytes.Equal(sum[:], want) {
+						return nil
+					}
+				}
+				return fmt.Errorf("bootstrap: no certificate matched pinned fingerprint")
+			},
+		},
+	}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, caURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: build ca request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: fetch ca root: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: read ca root: %v", err)
+	}
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, fmt.Errorf("bootstrap: ca root is not PEM-encoded")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// buildCSR builds a DER-encoded PKCS#10 certificate request for subject,
+// with sans attached as DNS SANs.
+func buildCSR(key *ecdsa.PrivateKey, subject string, sans []string) ([]byte, error) {
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: subject},
+		DNSNames: sans,
+	}
+	return x509.CreateCertificateRequest(rand.Reader, template, key)
+}
+
+// signCSR submits a DER-encoded CSR to the CA's sign endpoint and returns the
+// DER-encoded certificate chain it issues. If bearer is non-empty it is sent
+// as a Bearer Authorization header; otherwise the request relies on the
+// client's configured mTLS identity.
+func signCSR(ctx context.Context, client *http.Client, caURL string, csrDER []byte, bearer string) ([][]byte, error) {
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(caURL, "/")+"/sign", bytes.NewReader(csrPEM))
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: build sign request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-pem-file")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: sign csr: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bootstrap: ca sign endpoint returned %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap: read sign response: %v", err)
+	}
+
+	var chain [][]byte
+	rest := body
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		chain = append(chain, block.Bytes)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("bootstrap: sign response contained no certificates")
+	}
+	return chain, nil
+}
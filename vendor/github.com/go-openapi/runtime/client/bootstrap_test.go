@@ -0,0 +1,167 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func generateTestCertPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-ca"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func fingerprintHex(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchPinnedCA_AcceptsMatchingFingerprint(t *testing.T) {
+	caPEM := generateTestCertPEM(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(caPEM)
+	}))
+	defer srv.Close()
+
+	fp := fingerprintHex(srv.Certificate())
+	cert, err := fetchPinnedCA(context.Background(), srv.URL, fp)
+	if err != nil {
+		t.Fatalf("fetchPinnedCA: %v", err)
+	}
+	if cert.Subject.CommonName != "test-ca" {
+		t.Fatalf("cert.Subject.CommonName = %q, want test-ca", cert.Subject.CommonName)
+	}
+}
+
+func TestFetchPinnedCA_RejectsMismatchedFingerprint(t *testing.T) {
+	caPEM := generateTestCertPEM(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(caPEM)
+	}))
+	defer srv.Close()
+
+	wrongFP := hex.EncodeToString(make([]byte, sha256.Size))
+	if _, err := fetchPinnedCA(context.Background(), srv.URL, wrongFP); err == nil {
+		t.Fatal("fetchPinnedCA: expected error for mismatched fingerprint, got nil")
+	}
+}
+
+func TestSignCSR_SendsBearerTokenAndParsesChain(t *testing.T) {
+	caPEM := generateTestCertPEM(t)
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write(caPEM)
+	}))
+	defer srv.Close()
+
+	chain, err := signCSR(context.Background(), srv.Client(), srv.URL, []byte("csr-bytes"), "test-token")
+	if err != nil {
+		t.Fatalf("signCSR: %v", err)
+	}
+	if gotAuth != "Bearer test-token" {
+		t.Fatalf("Authorization header = %q, want %q", gotAuth, "Bearer test-token")
+	}
+	if len(chain) != 1 {
+		t.Fatalf("chain length = %d, want 1", len(chain))
+	}
+}
+
+// TestRunRenewal_BacksOffOnRepeatedFailure reproduces the renewal retry
+// storm: a leaf certificate whose 2/3-lifetime renewal point has already
+// passed, paired with a CA sign endpoint that always fails. Before the fix,
+// this hammered the CA hundreds of times within a couple hundred
+// milliseconds; with backoff in place the attempt count must stay small.
+func TestRunRenewal_BacksOffOnRepeatedFailure(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	caPEM := generateTestCertPEM(t)
+	block, _ := pem.Decode(caPEM)
+	caCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse test ca: %v", err)
+	}
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	expiredLeaf := &x509.Certificate{
+		NotBefore: time.Now().Add(-2 * time.Hour),
+		NotAfter:  time.Now().Add(-time.Hour), // already past its 2/3 renewal point
+	}
+	identity := &bootstrapIdentity{}
+	identity.set(tls.Certificate{PrivateKey: key}, expiredLeaf)
+
+	// Use a much smaller backoff floor than production so the test runs fast
+	// while still exercising the same capped-exponential-backoff code path.
+	original := renewalBackoffPolicy
+	renewalBackoffPolicy = RetryPolicy{MinBackoff: 20 * time.Millisecond, MaxBackoff: 200 * time.Millisecond}
+	defer func() { renewalBackoffPolicy = original }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		runRenewal(ctx, identity, srv.URL, "client.example", nil, caPool)
+		close(done)
+	}()
+	<-done
+
+	got := atomic.LoadInt32(&attempts)
+	if got > 15 {
+		t.Fatalf("attempts = %d in 200ms, want a small, backed-off number (bug reproduction saw 500+)", got)
+	}
+	if got < 1 {
+		t.Fatalf("attempts = %d, want at least 1", got)
+	}
+}
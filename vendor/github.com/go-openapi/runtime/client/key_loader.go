@@ -0,0 +1,111 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/youmark/pkcs8"
+)
+
+// loadX509KeyPair reads opts.Certificate and opts.Key from disk, transparently
+// decrypting opts.Key first if it is password protected, and returns the
+// resulting tls.Certificate.
+func loadX509KeyPair(opts TLSClientOptions) (tls.Certificate, error) {
+	certPEM, err := ioutil.ReadFile(opts.Certificate)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("tls client cert: %v", err)
+	}
+
+	keyPEM, err := ioutil.ReadFile(opts.Key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("tls client cert: %v", err)
+	}
+
+	keyPEM, err = decryptKeyPEM(keyPEM, opts)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("tls client cert: %v", err)
+	}
+	return cert, nil
+}
+
+// decryptKeyPEM inspects a PEM-encoded private key and, if it is encrypted,
+// decrypts it using the password supplied through opts, returning an
+// unencrypted PEM block suitable for tls.X509KeyPair. Unencrypted keys are
+// returned unchanged.
+func decryptKeyPEM(keyPEM []byte, opts TLSClientOptions) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("tls client key: no PEM data found")
+	}
+
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		password, err := keyPassword(opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(password) == 0 {
+			return nil, fmt.Errorf("tls client key: encrypted PKCS#8 key requires KeyPassword or KeyPasswordFunc")
+		}
+		key, _, err := pkcs8.ParsePrivateKey(block.Bytes, password)
+		if err != nil {
+			return nil, fmt.Errorf("tls client key: decrypt pkcs8 key: %v", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("tls client key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+
+	case x509.IsEncryptedPEMBlock(block): //nolint:staticcheck // legacy "Proc-Type: 4,ENCRYPTED" PEM, still encountered in the wild
+		password, err := keyPassword(opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(password) == 0 {
+			return nil, fmt.Errorf("tls client key: encrypted %s requires KeyPassword or KeyPasswordFunc", block.Type)
+		}
+		der, err := x509.DecryptPEMBlock(block, password) //nolint:staticcheck // see above
+		if err != nil {
+			return nil, fmt.Errorf("tls client key: decrypt %s: %v", block.Type, err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+
+	default:
+		return keyPEM, nil
+	}
+}
+
+// keyPassword resolves the password to use for decrypting Key, preferring
+// KeyPasswordFunc when both are set.
+func keyPassword(opts TLSClientOptions) ([]byte, error) {
+	if opts.KeyPasswordFunc != nil {
+		return opts.KeyPasswordFunc()
+	}
+	if opts.KeyPassword != "" {
+		return []byte(opts.KeyPassword), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/youmark/pkcs8"
+)
+
+// selfSignedTemplate returns a fresh, minimal self-signed certificate
+// template suitable for both the subject and issuer of x509.CreateCertificate
+// in these tests.
+func selfSignedTemplate() *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func generateTestKeyAndCertPEM(t *testing.T) (certPEM, keyPEM []byte, key *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, selfSignedTemplate(), selfSignedTemplate(), &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM, key
+}
+
+func TestLoadX509KeyPair_PlainKey(t *testing.T) {
+	certPEM, keyPEM, _ := generateTestKeyAndCertPEM(t)
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	writeFile(t, certPath, certPEM)
+	writeFile(t, keyPath, keyPEM)
+
+	if _, err := loadX509KeyPair(TLSClientOptions{Certificate: certPath, Key: keyPath}); err != nil {
+		t.Fatalf("loadX509KeyPair: %v", err)
+	}
+}
+
+func TestLoadX509KeyPair_EncryptedPKCS8Key(t *testing.T) {
+	certPEM, _, key := generateTestKeyAndCertPEM(t)
+	der, err := pkcs8.MarshalPrivateKey(key, []byte("hunter2"), nil)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	writeFile(t, certPath, certPEM)
+	writeFile(t, keyPath, keyPEM)
+
+	if _, err := loadX509KeyPair(TLSClientOptions{Certificate: certPath, Key: keyPath}); err == nil {
+		t.Fatal("want an error when no password is supplied for an encrypted key")
+	}
+
+	opts := TLSClientOptions{Certificate: certPath, Key: keyPath, KeyPassword: "hunter2"}
+	if _, err := loadX509KeyPair(opts); err != nil {
+		t.Fatalf("loadX509KeyPair with correct password: %v", err)
+	}
+
+	opts.KeyPassword = "wrong"
+	if _, err := loadX509KeyPair(opts); err == nil {
+		t.Fatal("want an error for a wrong password")
+	}
+}
+
+func TestLoadX509KeyPair_KeyPasswordFuncTakesPrecedence(t *testing.T) {
+	certPEM, _, key := generateTestKeyAndCertPEM(t)
+	der, err := pkcs8.MarshalPrivateKey(key, []byte("from-func"), nil)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	writeFile(t, certPath, certPEM)
+	writeFile(t, keyPath, keyPEM)
+
+	opts := TLSClientOptions{
+		Certificate:     certPath,
+		Key:             keyPath,
+		KeyPassword:     "wrong",
+		KeyPasswordFunc: func() ([]byte, error) { return []byte("from-func"), nil },
+	}
+	if _, err := loadX509KeyPair(opts); err != nil {
+		t.Fatalf("loadX509KeyPair: KeyPasswordFunc should take precedence over KeyPassword: %v", err)
+	}
+}
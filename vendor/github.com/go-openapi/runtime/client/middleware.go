@@ -0,0 +1,74 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Middleware wraps an http.RoundTripper with additional behavior, composing
+// the same way standard middleware does: the returned RoundTripper calls
+// next to perform the actual round trip.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface, for
+// use both by the Middleware implementations in this package and by
+// Middleware built in other packages (e.g. the optional otelmw/prommw
+// sub-packages).
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use composes mws, in the order given, onto whichever transport is
+// currently active for r (r.client.Transport once a client has been built,
+// otherwise r.Transport, falling back to http.DefaultTransport), and
+// installs the result as the new active transport. Call it before the first
+// Submit, or after, since Submit always re-reads r.Transport/r.client.
+func (r *Runtime) Use(mws ...Middleware) {
+	base := transportOrDefault(r.Transport, http.DefaultTransport)
+	if r.client != nil {
+		base = transportOrDefault(r.client.Transport, base)
+	}
+	for _, mw := range mws {
+		base = mw(base)
+	}
+	r.Transport = base
+	if r.client != nil {
+		r.client.Transport = base
+	}
+}
+
+// operationIDContextKey is the context key Submit uses to publish the
+// current runtime.ClientOperation.ID so middleware can label metrics and
+// spans by operation without threading it through every call.
+type operationIDContextKey struct{}
+
+func contextWithOperationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, operationIDContextKey{}, id)
+}
+
+// OperationIDFromContext returns the ID of the runtime.ClientOperation
+// driving the in-flight request, as published by Submit, for use by
+// Middleware implementations.
+func OperationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(operationIDContextKey{}).(string)
+	return id, ok
+}
@@ -0,0 +1,152 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+
+	"github.com/go-openapi/runtime/logger"
+)
+
+var errNoReplayNonce = errors.New("client: new-nonce endpoint did not return a Replay-Nonce header")
+
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+}
+
+// LoggingMiddleware dumps each request and response through logger, with
+// Authorization and Cookie header values replaced by "REDACTED".
+func LoggingMiddleware(log logger.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			dumpReq := redactedRequest(req)
+			reqDump, err := httputil.DumpRequestOut(dumpReq, true)
+			if err == nil {
+				log.Debugf("%s\n", string(reqDump))
+			}
+			// The clone shares req's Body, which the dump drained and replaced
+			// with a buffered copy; hand that copy back to the live request.
+			if req.Body != nil {
+				req.Body = dumpReq.Body
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				return nil, err
+			}
+
+			dumpResp := redactedResponse(resp)
+			respDump, err := httputil.DumpResponse(dumpResp, true)
+			if err == nil {
+				log.Debugf("%s\n", string(respDump))
+			}
+			// Same for the response body, so the caller can still read it.
+			resp.Body = dumpResp.Body
+			return resp, nil
+		})
+	}
+}
+
+func redactedRequest(req *http.Request) *http.Request {
+	clone := req.Clone(req.Context())
+	redactHeaders(clone.Header)
+	return clone
+}
+
+func redactedResponse(resp *http.Response) *http.Response {
+	clone := *resp
+	clone.Header = resp.Header.Clone()
+	redactHeaders(clone.Header)
+	return &clone
+}
+
+func redactHeaders(h http.Header) {
+	for name := range h {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			h.Set(name, "REDACTED")
+		}
+	}
+}
+
+// nonceCache serves a cache of ACME Replay-Nonce values, fetching a fresh
+// one from newNonceURL when empty.
+type nonceCache struct {
+	client      *http.Client
+	newNonceURL string
+
+	mu     sync.Mutex
+	cached string
+}
+
+// NewNonceCacheMiddleware attaches a Replay-Nonce header to every POST
+// request that doesn't already carry one, transparently fetching a fresh
+// nonce from newNonceURL (via HEAD, per the ACME protocol) when the cache is
+// empty, and refills the cache from each response's Replay-Nonce header.
+func NewNonceCacheMiddleware(newNonceURL string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		cache := &nonceCache{client: &http.Client{Transport: next}, newNonceURL: newNonceURL}
+
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPost && req.Header.Get("Replay-Nonce") == "" {
+				nonce, err := cache.get()
+				if err != nil {
+					return nil, err
+				}
+				req.Header.Set("Replay-Nonce", nonce)
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				if nonce := resp.Header.Get("Replay-Nonce"); nonce != "" {
+					cache.put(nonce)
+				}
+			}
+			return resp, err
+		})
+	}
+}
+
+func (c *nonceCache) get() (string, error) {
+	c.mu.Lock()
+	if c.cached != "" {
+		nonce := c.cached
+		c.cached = ""
+		c.mu.Unlock()
+		return nonce, nil
+	}
+	c.mu.Unlock()
+
+	resp, err := c.client.Head(c.newNonceURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	nonce := resp.Header.Get("Replay-Nonce")
+	if nonce == "" {
+		return "", errNoReplayNonce
+	}
+	return nonce, nil
+}
+
+func (c *nonceCache) put(nonce string) {
+	c.mu.Lock()
+	c.cached = nonce
+	c.mu.Unlock()
+}
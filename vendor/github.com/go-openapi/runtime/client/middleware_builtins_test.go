@@ -0,0 +1,133 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type fakeLogger struct {
+	lines []string
+}
+
+func (f *fakeLogger) Printf(format string, args ...interface{}) {}
+
+func (f *fakeLogger) Debugf(format string, args ...interface{}) {
+	f.lines = append(f.lines, fmt.Sprintf(format, args...))
+}
+
+func TestLoggingMiddleware_RedactsSensitiveHeaders(t *testing.T) {
+	log := &fakeLogger{}
+	rt := LoggingMiddleware(log)(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}
+		resp.Header.Set("Set-Cookie", "session=leaked")
+		return resp, nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "session=secret")
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	// The real request actually sent must be untouched; only the dumped copy
+	// used for logging is redacted.
+	if req.Header.Get("Authorization") != "Bearer secret-token" {
+		t.Fatalf("Authorization header mutated on the live request: %q", req.Header.Get("Authorization"))
+	}
+
+	dump := strings.Join(log.lines, "\n")
+	if strings.Contains(dump, "secret-token") || strings.Contains(dump, "session=secret") {
+		t.Fatalf("log dump leaked a redacted header: %q", dump)
+	}
+}
+
+func TestNewNonceCacheMiddleware_FetchesNonceWhenCacheEmpty(t *testing.T) {
+	var headRequests int
+	var postedNonces []string
+
+	newNonceSrv := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodHead {
+			headRequests++
+			h := http.Header{}
+			h.Set("Replay-Nonce", "nonce-from-head")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: h}, nil
+		}
+		postedNonces = append(postedNonces, req.Header.Get("Replay-Nonce"))
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	})
+
+	rt := NewNonceCacheMiddleware("http://example.test/new-nonce")(newNonceSrv)
+
+	req1, _ := http.NewRequest(http.MethodPost, "http://example.test/submit", nil)
+	if _, err := rt.RoundTrip(req1); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	req2, _ := http.NewRequest(http.MethodPost, "http://example.test/submit", nil)
+	if _, err := rt.RoundTrip(req2); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	if headRequests != 2 {
+		t.Fatalf("headRequests = %d, want 2 (cache empty every time in this test, since responses carry no Replay-Nonce)", headRequests)
+	}
+	if len(postedNonces) != 2 || postedNonces[0] != "nonce-from-head" || postedNonces[1] != "nonce-from-head" {
+		t.Fatalf("postedNonces = %v, want both requests to carry the fetched nonce", postedNonces)
+	}
+}
+
+func TestNewNonceCacheMiddleware_CachesNonceFromResponse(t *testing.T) {
+	var headRequests int
+
+	backend := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodHead {
+			headRequests++
+			h := http.Header{}
+			h.Set("Replay-Nonce", "initial-nonce")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: h}, nil
+		}
+		h := http.Header{}
+		h.Set("Replay-Nonce", "nonce-from-response")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: h}, nil
+	})
+
+	var sentNonces []string
+	rt := NewNonceCacheMiddleware("http://example.test/new-nonce")(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := backend.RoundTrip(req)
+		if err == nil && req.Method == http.MethodPost {
+			sentNonces = append(sentNonces, req.Header.Get("Replay-Nonce"))
+		}
+		return resp, err
+	}))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "http://example.test/submit", nil)
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip: %v", err)
+		}
+	}
+
+	if headRequests != 1 {
+		t.Fatalf("headRequests = %d, want 1 (second call should reuse the nonce cached from the first response)", headRequests)
+	}
+	if len(sentNonces) != 2 || sentNonces[0] != "initial-nonce" || sentNonces[1] != "nonce-from-response" {
+		t.Fatalf("sentNonces = %v, want [initial-nonce nonce-from-response]", sentNonces)
+	}
+}
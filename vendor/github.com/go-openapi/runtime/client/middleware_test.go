@@ -0,0 +1,74 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestUse_ComposesInOrder(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	r := &Runtime{Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "base")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	})}
+	// Use composes each middleware onto the result of the previous one, so
+	// the last middleware given ends up outermost and runs first.
+	r.Use(mw("first"), mw("second"))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	if _, err := r.Transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	want := []string{"second", "first", "base"}
+	if len(order) != len(want) {
+		t.Fatalf("call order = %v, want %v", order, want)
+	}
+	for i, name := range want {
+		if order[i] != name {
+			t.Fatalf("call order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestOperationIDFromContext(t *testing.T) {
+	if _, ok := OperationIDFromContext(context.Background()); ok {
+		t.Fatal("OperationIDFromContext on bare context: ok = true, want false")
+	}
+
+	ctx := contextWithOperationID(context.Background(), "getAlerts")
+	id, ok := OperationIDFromContext(ctx)
+	if !ok || id != "getAlerts" {
+		t.Fatalf("OperationIDFromContext = (%q, %v), want (getAlerts, true)", id, ok)
+	}
+
+	// Attaching an empty ID must not shadow a previously attached one.
+	ctx = contextWithOperationID(ctx, "")
+	if id, ok := OperationIDFromContext(ctx); !ok || id != "getAlerts" {
+		t.Fatalf("OperationIDFromContext after empty overwrite = (%q, %v), want (getAlerts, true)", id, ok)
+	}
+}
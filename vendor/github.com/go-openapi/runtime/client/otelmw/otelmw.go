@@ -0,0 +1,67 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otelmw provides an OpenTelemetry client.Middleware. It is a
+// separate package from client so that importing client doesn't pull in the
+// OpenTelemetry SDK for consumers who don't want tracing.
+package otelmw
+
+import (
+	"net/http"
+
+	"github.com/go-openapi/runtime/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New starts a client span around each request, named after the operation
+// ID when one is available, and injects its context into the outgoing
+// request headers through the global TextMapPropagator in effect when New is
+// called (a W3C traceparent header with propagation.TraceContext). otel's
+// default propagator writes nothing, so no header is injected unless one has
+// been installed with otel.SetTextMapPropagator.
+func New(tracerName string) client.Middleware {
+	tracer := otel.Tracer(tracerName)
+	propagator := otel.GetTextMapPropagator()
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			spanName := req.Method + " " + req.URL.Path
+			if id, ok := client.OperationIDFromContext(req.Context()); ok {
+				spanName = id
+			}
+
+			ctx, span := tracer.Start(req.Context(), spanName, trace.WithSpanKind(trace.SpanKindClient))
+			defer span.End()
+
+			req = req.WithContext(ctx)
+			propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, err
+			}
+			span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+			return resp, nil
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otelmw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-openapi/runtime/client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var errRoundTrip = errors.New("round trip failed")
+
+func TestNew_InjectsTraceparentHeader(t *testing.T) {
+	// otel's defaults (no-op TracerProvider and propagator) produce an
+	// invalid span context, which TraceContext.Inject skips; install a real
+	// SDK TracerProvider and the standard W3C propagator so a traceparent is
+	// actually written.
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample())))
+	defer otel.SetTracerProvider(prevProvider)
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	var gotTraceparent string
+	rt := New("test-tracer")(client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotTraceparent = req.Header.Get("traceparent")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	req = req.WithContext(context.Background())
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	if gotTraceparent == "" {
+		t.Fatal("traceparent header was not injected into the outgoing request")
+	}
+}
+
+func TestNew_PropagatesRoundTripError(t *testing.T) {
+	rt := New("test-tracer")(client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errRoundTrip
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	if _, err := rt.RoundTrip(req); err != errRoundTrip {
+		t.Fatalf("RoundTrip error = %v, want %v", err, errRoundTrip)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prommw provides a Prometheus client.Middleware. It is a separate
+// package from client so that importing client doesn't pull in the
+// Prometheus client for consumers who don't want metrics.
+package prommw
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/runtime/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// New registers a request duration histogram labeled by operation ID and
+// returns a client.Middleware that observes it.
+func New(registerer prometheus.Registerer) (client.Middleware, error) {
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "alertmanager_client_request_duration_seconds",
+		Help:    "Duration of Alertmanager API client requests, by operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "code"})
+
+	if err := registerer.Register(histogram); err != nil {
+		return nil, err
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			operation, _ := client.OperationIDFromContext(req.Context())
+			start := time.Now()
+
+			resp, err := next.RoundTrip(req)
+
+			code := "error"
+			if resp != nil {
+				code = strings.ToUpper(http.StatusText(resp.StatusCode))
+			}
+			histogram.WithLabelValues(operation, code).Observe(time.Since(start).Seconds())
+			return resp, err
+		})
+	}, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommw
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-openapi/runtime/client"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// TestNew_ObservesRequestDuration exercises New without an operation ID in
+// context, since attaching one is a client-internal detail of Submit that
+// this package has no way to set directly. The operation label is not
+// checked here; the client package's TestOperationIDFromContext covers only
+// the context key the label is read from.
+func TestNew_ObservesRequestDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	mw, err := New(registry)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	rt := mw(client.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	var found *dto.Metric
+	for _, mf := range families {
+		if mf.GetName() != "alertmanager_client_request_duration_seconds" {
+			continue
+		}
+		if len(mf.Metric) > 0 {
+			found = mf.Metric[0]
+		}
+	}
+	if found == nil {
+		t.Fatal("no histogram observation recorded")
+	}
+	if found.Histogram.GetSampleCount() != 1 {
+		t.Fatalf("sample count = %d, want 1", found.Histogram.GetSampleCount())
+	}
+}
+
+func TestNew_RejectsDuplicateRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := New(registry); err != nil {
+		t.Fatalf("first New: %v", err)
+	}
+	if _, err := New(registry); err == nil {
+		t.Fatal("second New on the same registry: want an AlreadyRegistered error, got nil")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how Runtime.Submit retries a failed request.
+type RetryPolicy struct {
+	// MaxRetries is the number of additional attempts made after the first
+	// one fails. Zero disables retries.
+	MaxRetries int
+
+	// MinBackoff and MaxBackoff bound the exponential backoff applied
+	// between attempts. The actual delay is chosen with full jitter in
+	// [0, min(MaxBackoff, MinBackoff*2^attempt)).
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RetryableStatusCodes lists the HTTP status codes that are retried. A
+	// response with any other status code is returned to the caller as-is.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy is the policy New populates a Runtime with: up to 3
+// retries, 100ms-2s exponential backoff with full jitter, retrying network
+// errors and HTTP 429/502/503/504.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:           3,
+		MinBackoff:           100 * time.Millisecond,
+		MaxBackoff:           2 * time.Second,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+func (p RetryPolicy) isRetryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	cap := p.MinBackoff << uint(attempt)
+	if cap <= 0 || cap > p.MaxBackoff {
+		cap = p.MaxBackoff
+	}
+	if cap <= 0 {
+		// A policy with MinBackoff/MaxBackoff left at zero (e.g. only
+		// MaxRetries was set) has no backoff window to jitter within;
+		// rand.Int63n(0) panics, so retry immediately instead.
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(cap)))
+}
+
+// retryContextKey is the type of the context key used to carry a per-call
+// RetryOverride, kept unexported so only WithRetryOverride can set it.
+type retryContextKey struct{}
+
+// RetryOverride customizes Submit's retry behavior for a single
+// runtime.ClientOperation, overriding the Runtime's RetryPolicy.
+type RetryOverride struct {
+	// Policy, if non-nil, replaces the Runtime's RetryPolicy for this call.
+	Policy *RetryPolicy
+
+	// AllowNonIdempotent opts a non-idempotent method (e.g. POST, PATCH)
+	// into being retried. Idempotent methods are always eligible.
+	AllowNonIdempotent bool
+}
+
+// WithRetryOverride attaches a RetryOverride to ctx for use as an
+// operation's Context, letting an individual generated call customize retry
+// behavior without changing the Runtime's default RetryPolicy.
+func WithRetryOverride(ctx context.Context, override RetryOverride) context.Context {
+	return context.WithValue(ctx, retryContextKey{}, override)
+}
+
+func retryOverrideFromContext(ctx context.Context) (RetryOverride, bool) {
+	override, ok := ctx.Value(retryContextKey{}).(RetryOverride)
+	return override, ok
+}
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodTrace:   true,
+}
+
+// ensureReplayableBody makes req.Body replayable by buffering it once and
+// installing a GetBody that returns a fresh reader over the buffered bytes,
+// if req doesn't already have one (e.g. from http.NewRequestWithContext with
+// a *bytes.Reader body).
+func ensureReplayableBody(req *http.Request) error {
+	if req.Body == nil || req.GetBody != nil {
+		return nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	req.Body.Close()
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return nil
+}
+
+// doWithRetry executes req, retrying according to policy when the attempt
+// fails with a network error or a retryable status code. Non-idempotent
+// methods are only retried when allowNonIdempotent is set, and only when the
+// request body (if any) can be replayed via req.GetBody.
+func doWithRetry(ctx context.Context, client *http.Client, req *http.Request, policy RetryPolicy, allowNonIdempotent bool) (*http.Response, error) {
+	canRetry := policy.MaxRetries > 0 && (idempotentMethods[req.Method] || allowNonIdempotent)
+
+	// Only buffer the body when a retry could actually happen: buffering
+	// unconditionally would read every request body (however large) into
+	// memory up front, defeating streaming uploads for callers who never
+	// retry.
+	if canRetry {
+		if err := ensureReplayableBody(req); err != nil {
+			return nil, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			canRetry = false
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			// Clone with the request's own context, not the outer ctx: req's
+			// context may carry values (e.g. the operation ID) attached by
+			// the caller after ctx was created, and Clone replaces the
+			// context wholesale.
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		res, err := client.Do(attemptReq)
+		if err == nil && !policy.isRetryableStatus(res.StatusCode) {
+			return res, nil
+		}
+		if err == nil {
+			lastErr = nil
+		} else {
+			lastErr = err
+		}
+
+		if !canRetry || attempt >= policy.MaxRetries {
+			if err != nil {
+				return nil, err
+			}
+			return res, nil
+		}
+
+		delay := policy.backoff(attempt)
+		if res != nil {
+			if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			res.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header value, which is either a
+// number of delta-seconds or an HTTP-date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		d := time.Until(when)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -0,0 +1,295 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stubTransport invokes fn for every RoundTrip, letting tests observe the
+// request (and its context) that doWithRetry actually sends.
+type stubTransport struct {
+	fn func(*http.Request) (*http.Response, error)
+}
+
+func (s stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return s.fn(req)
+}
+
+func TestDoWithRetry_RetriesOnRetryableStatus(t *testing.T) {
+	var attempts int
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts < 3 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Header: http.Header{}}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, RetryableStatusCodes: []int{http.StatusServiceUnavailable}}
+
+	res, err := doWithRetry(context.Background(), client, req, policy, false)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", res.StatusCode)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestDoWithRetry_HonorsRetryAfterHeader(t *testing.T) {
+	var attempts int
+
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		attempts++
+
+		if attempts == 1 {
+			h := http.Header{}
+			h.Set("Retry-After", "0")
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody, Header: h}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	// A huge MaxBackoff would make the test flaky if Retry-After were ignored.
+	policy := RetryPolicy{MaxRetries: 1, MinBackoff: time.Hour, MaxBackoff: time.Hour, RetryableStatusCodes: []int{http.StatusTooManyRequests}}
+
+	start := time.Now()
+	res, err := doWithRetry(context.Background(), client, req, policy, false)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", res.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("retry took %s, Retry-After: 0 should have made it immediate", elapsed)
+	}
+}
+
+func TestDoWithRetry_NonIdempotentNotRetriedByDefault(t *testing.T) {
+	var attempts int
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.test", nil)
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryableStatusCodes: []int{http.StatusServiceUnavailable}}
+
+	res, err := doWithRetry(context.Background(), client, req, policy, false)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (non-idempotent methods must opt in to retries)", attempts)
+	}
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 to be returned as-is", res.StatusCode)
+	}
+}
+
+func TestDoWithRetry_PreservesRequestContextAcrossRetries(t *testing.T) {
+	var gotIDs []string
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		id, _ := OperationIDFromContext(req.Context())
+		gotIDs = append(gotIDs, id)
+		if len(gotIDs) < 3 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Header: http.Header{}}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	req = req.WithContext(contextWithOperationID(context.Background(), "getAlerts"))
+	policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryableStatusCodes: []int{http.StatusServiceUnavailable}}
+
+	if _, err := doWithRetry(context.Background(), client, req, policy, false); err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+
+	for i, id := range gotIDs {
+		if id != "getAlerts" {
+			t.Fatalf("attempt %d: operation ID = %q, want %q (lost on retry)", i, id, "getAlerts")
+		}
+	}
+}
+
+func TestRetryPolicy_BackoffWithZeroBoundsDoesNotPanic(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 1} // MinBackoff/MaxBackoff left at their zero value
+	if d := policy.backoff(0); d != 0 {
+		t.Fatalf("backoff() = %s, want 0 for an unconfigured backoff window", d)
+	}
+}
+
+func TestDoWithRetry_ZeroBackoffPolicyDoesNotPanic(t *testing.T) {
+	var attempts int
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts < 2 {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Header: http.Header{}}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	// MinBackoff/MaxBackoff unset: a caller that only sets MaxRetries must
+	// not panic on the first retryable failure.
+	policy := RetryPolicy{MaxRetries: 1, RetryableStatusCodes: []int{http.StatusServiceUnavailable}}
+
+	res, err := doWithRetry(context.Background(), client, req, policy, false)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if res.StatusCode != http.StatusOK || attempts != 2 {
+		t.Fatalf("status = %v, attempts = %d", res, attempts)
+	}
+}
+
+func TestDoWithRetry_DoesNotBufferBodyWhenRetriesDisabled(t *testing.T) {
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("streamed body"))
+		pw.Close()
+	}()
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.test", pr)
+	policy := RetryPolicy{MaxRetries: 0}
+
+	if _, err := doWithRetry(context.Background(), client, req, policy, false); err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if req.GetBody != nil {
+		t.Fatal("GetBody was installed even though MaxRetries is 0; body should not have been buffered")
+	}
+}
+
+func TestDoWithRetry_DoesNotBufferBodyForNonIdempotentWithoutOptIn(t *testing.T) {
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("streamed body"))
+		pw.Close()
+	}()
+
+	req, _ := http.NewRequest(http.MethodPost, "http://example.test", pr)
+	policy := RetryPolicy{MaxRetries: 3, RetryableStatusCodes: []int{http.StatusServiceUnavailable}}
+
+	if _, err := doWithRetry(context.Background(), client, req, policy, false); err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if req.GetBody != nil {
+		t.Fatal("GetBody was installed for a non-idempotent request that never opted into retries")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := []struct {
+		value   string
+		wantOK  bool
+		wantMin time.Duration
+	}{
+		{"", false, 0},
+		{"120", true, 120 * time.Second},
+		{"-1", false, 0},
+		{"not-a-value", false, 0},
+	}
+	for _, c := range cases {
+		d, ok := parseRetryAfter(c.value)
+		if ok != c.wantOK {
+			t.Errorf("parseRetryAfter(%q) ok = %v, want %v", c.value, ok, c.wantOK)
+			continue
+		}
+		if ok && d != c.wantMin {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", c.value, d, c.wantMin)
+		}
+	}
+
+	future := time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)
+	d, ok := parseRetryAfter(future)
+	if !ok {
+		t.Fatalf("parseRetryAfter(%q) ok = false, want true", future)
+	}
+	if d <= 0 || d > 3*time.Minute {
+		t.Fatalf("parseRetryAfter(%q) = %s, want ~2m", future, d)
+	}
+}
+
+func TestDoWithRetry_NetworkErrorRetried(t *testing.T) {
+	var attempts int
+	boom := fmt.Errorf("connection reset")
+	client := &http.Client{Transport: stubTransport{fn: func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts < 2 {
+			return nil, boom
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	}}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.test", nil)
+	policy := RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	res, err := doWithRetry(context.Background(), client, req, policy, false)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if res.StatusCode != http.StatusOK || attempts != 2 {
+		t.Fatalf("status = %v, attempts = %d", res, attempts)
+	}
+}
+
+// Ensures the test file compiles against a real listener too, guarding
+// against the stub transport masking issues in request cloning.
+func TestDoWithRetry_AgainstRealServer(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	policy := RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryableStatusCodes: []int{http.StatusBadGateway}}
+
+	res, err := doWithRetry(context.Background(), srv.Client(), req, policy, false)
+	if err != nil {
+		t.Fatalf("doWithRetry: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", res.StatusCode)
+	}
+}
@@ -17,8 +17,6 @@ package client
 import (
 	"context"
 	"crypto"
-	"crypto/ecdsa"
-	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
@@ -48,10 +46,21 @@ type TLSClientOptions struct {
 	// is also required.
 	LoadedCertificate *x509.Certificate
 
-	// Key is the path to an unencrypted PEM-encoded private key for client
-	// authentication. This field is required if Certificate is set.
+	// Key is the path to a PEM-encoded private key for client authentication.
+	// This field is required if Certificate is set. The key may be encrypted,
+	// in which case KeyPassword or KeyPasswordFunc must also be set.
 	Key string
 
+	// KeyPassword decrypts Key when it holds an encrypted private key. It is
+	// ignored if KeyPasswordFunc is set.
+	KeyPassword string
+
+	// KeyPasswordFunc, if set, is called to obtain the password used to
+	// decrypt Key, taking precedence over KeyPassword. Use this to avoid
+	// keeping the password in memory for the lifetime of the options, or to
+	// prompt for it lazily.
+	KeyPasswordFunc func() ([]byte, error)
+
 	// LoadedKey is the key for client authentication. This field is required if
 	// LoadedCertificate is set.
 	LoadedKey crypto.PrivateKey
@@ -74,11 +83,54 @@ type TLSClientOptions struct {
 	// InsecureSkipVerify controls whether the certificate chain and hostname presented
 	// by the server are validated. If false, any certificate is accepted.
 	InsecureSkipVerify bool
-	
-	// MinVersion specifies the version of TLS used.
-	// If it is not set, the default value will be selected deferred to the Go crypto/tls library.
+
+	// MinVersion specifies the minimum version of TLS accepted, by name (e.g.
+	// "TLS12", "TLS13"). If it is not set, the default value will be selected
+	// deferred to the Go crypto/tls library.
 	MinVersion string
 
+	// MaxVersion specifies the maximum version of TLS accepted, by name. If
+	// it is not set, the default value will be selected deferred to the Go
+	// crypto/tls library.
+	MaxVersion string
+
+	// CipherSuites restricts the cipher suites offered to the Go standard
+	// names (e.g. "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"). If empty, the
+	// crypto/tls default list is used. Ignored for TLS 1.3, which does not
+	// allow the cipher suite to be configured.
+	CipherSuites []string
+
+	// CurvePreferences restricts the elliptic curves used during the
+	// handshake, by Go standard name (e.g. "X25519", "P256"). If empty, the
+	// crypto/tls default preference order is used.
+	CurvePreferences []string
+
+	// NextProtos lists the supported application protocols for ALPN
+	// negotiation, most preferred first (e.g. "h2", "http/1.1").
+	NextProtos []string
+
+	// Profile applies a curated set of the above as a single shorthand:
+	// "modern" (TLS 1.3 only), "intermediate" (TLS 1.2+ with a vetted AEAD
+	// cipher/curve list), or "fips" (TLS 1.2+ restricted to FIPS 140-2
+	// approved algorithms). Fields set explicitly alongside Profile take
+	// precedence over the profile's defaults.
+	Profile string
+
+	// CertRefresh, when non-zero, causes the client certificate identified by
+	// Certificate and Key to be re-read from disk on this interval. The pair
+	// is only re-parsed when the Certificate file's mtime or size changes, so
+	// a short interval is cheap to set; a change to the Key file alone is not
+	// detected. This field is ignored unless Certificate and Key are both set
+	// to file paths.
+	CertRefresh time.Duration
+
+	// CARefresh, when non-zero, causes the CA certificate identified by CA to
+	// be re-read from disk on this interval, following the same change
+	// detection as CertRefresh. This field is ignored unless CA is set to a
+	// file path and LoadedCA is not set. When both CertRefresh and CARefresh
+	// are set, both files are checked on the shorter of the two intervals.
+	CARefresh time.Duration
+
 	// Prevents callers using unkeyed fields.
 	_ struct{}
 }
@@ -90,27 +142,18 @@ func TLSClientAuth(opts TLSClientOptions) (*tls.Config, error) {
 
 	// load client cert if specified
 	if opts.Certificate != "" {
-		cert, err := tls.LoadX509KeyPair(opts.Certificate, opts.Key)
+		cert, err := loadX509KeyPair(opts)
 		if err != nil {
-			return nil, fmt.Errorf("tls client cert: %v", err)
+			return nil, err
 		}
 		cfg.Certificates = []tls.Certificate{cert}
 	} else if opts.LoadedCertificate != nil {
 		block := pem.Block{Type: "CERTIFICATE", Bytes: opts.LoadedCertificate.Raw}
 		certPem := pem.EncodeToMemory(&block)
 
-		var keyBytes []byte
-		switch k := opts.LoadedKey.(type) {
-		case *rsa.PrivateKey:
-			keyBytes = x509.MarshalPKCS1PrivateKey(k)
-		case *ecdsa.PrivateKey:
-			var err error
-			keyBytes, err = x509.MarshalECPrivateKey(k)
-			if err != nil {
-				return nil, fmt.Errorf("tls client priv key: %v", err)
-			}
-		default:
-			return nil, fmt.Errorf("tls client priv key: unsupported key type")
+		keyBytes, err := x509.MarshalPKCS8PrivateKey(opts.LoadedKey)
+		if err != nil {
+			return nil, fmt.Errorf("tls client priv key: %v", err)
 		}
 
 		block = pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}
@@ -149,6 +192,10 @@ func TLSClientAuth(opts TLSClientOptions) (*tls.Config, error) {
 		cfg.ServerName = opts.ServerName
 	}
 
+	if err := applyTLSProtocolOptions(cfg, opts); err != nil {
+		return nil, err
+	}
+
 	cfg.BuildNameToCertificate()
 
 	return cfg, nil
@@ -161,6 +208,10 @@ func TLSTransport(opts TLSClientOptions) (http.RoundTripper, error) {
 		return nil, err
 	}
 
+	if opts.CertRefresh > 0 || opts.CARefresh > 0 {
+		return newReloadingTransport(opts, cfg)
+	}
+
 	return &http.Transport{TLSClientConfig: cfg}, nil
 }
 
@@ -192,12 +243,36 @@ type Runtime struct {
 	Formats  strfmt.Registry
 	Context  context.Context
 
+	// Hosts, when non-empty, lists the host:port endpoints that EndpointSelector
+	// picks from for each Submit call, letting a Runtime front multiple
+	// replicas without an external load balancer. Host is used as a fallback
+	// when Hosts is empty.
+	Hosts []string
+
+	// EndpointSelector picks which of Hosts to use for a given Submit call.
+	// It defaults to a round-robin selector the first time Hosts is non-empty.
+	EndpointSelector EndpointSelector
+
 	Debug  bool
 	logger logger.Logger
 
-	clientOnce *sync.Once
-	client     *http.Client
-	schemes    []string
+	// RetryPolicy controls how Submit retries failed requests. See
+	// DefaultRetryPolicy for the defaults New populates it with.
+	RetryPolicy RetryPolicy
+
+	clientOnce   *sync.Once
+	client       *http.Client
+	schemes      []string
+	selectorOnce *sync.Once
+
+	// unixSocketScheme forces the scheme used on outgoing requests when Host
+	// was given as a unix:// or http+unix:// socket address.
+	unixSocketScheme string
+
+	// bootstrap holds the mTLS identity obtained through
+	// NewFromBootstrapToken, if any, along with its renewal lifecycle.
+	bootstrap     *bootstrapIdentity
+	renewalCancel context.CancelFunc
 }
 
 // New creates a new default runtime for a swagger api runtime.Client
@@ -224,14 +299,22 @@ func New(host, basePath string, schemes []string) *Runtime {
 	rt.Jar = nil
 	rt.Host = host
 	rt.BasePath = basePath
+
+	if socketPath, ok := unixSocketPath(host); ok {
+		rt.Transport = unixSocketTransport(socketPath)
+		rt.Host = "unix"
+		rt.unixSocketScheme = "http"
+	}
 	rt.Context = context.Background()
 	rt.clientOnce = new(sync.Once)
+	rt.selectorOnce = new(sync.Once)
 	if !strings.HasPrefix(rt.BasePath, "/") {
 		rt.BasePath = "/" + rt.BasePath
 	}
 
 	rt.Debug = logger.DebugEnabled()
 	rt.logger = logger.StandardLogger{}
+	rt.RetryPolicy = DefaultRetryPolicy()
 
 	if len(schemes) > 0 {
 		rt.schemes = schemes
@@ -240,14 +323,28 @@ func New(host, basePath string, schemes []string) *Runtime {
 }
 
 // NewWithClient allows you to create a new transport with a configured http.Client
-func NewWithClient(host, basePath string, schemes []string, client *http.Client) *Runtime {
+func NewWithClient(host, basePath string, schemes []string, client *http.Client) (*Runtime, error) {
 	rt := New(host, basePath, schemes)
 	if client != nil {
+		if rt.unixSocketScheme != "" {
+			// host was a unix:// or http+unix:// address: New already built
+			// the unix-socket-dialing Transport onto rt.Transport, but that's
+			// about to be discarded in favor of client's. Splice it in when
+			// client has no Transport of its own; refuse to guess when it
+			// does, since silently keeping a TCP-dialing transport around
+			// would route requests to a literal host named "unix".
+			switch {
+			case client.Transport == nil:
+				client.Transport = rt.Transport
+			default:
+				return nil, fmt.Errorf("client: host %q is a unix socket address, but the supplied client already has a Transport; leave client.Transport nil so the unix socket dialer can be installed", host)
+			}
+		}
 		rt.clientOnce.Do(func() {
 			rt.client = client
 		})
 	}
-	return rt
+	return rt, nil
 }
 
 func (r *Runtime) pickScheme(schemes []string) string {
@@ -278,6 +375,21 @@ func (r *Runtime) selectScheme(schemes []string) string {
 	}
 	return scheme
 }
+
+// endpointSelector returns the EndpointSelector to use for this Submit call,
+// initializing a default round-robin one exactly once (guarded by
+// selectorOnce, mirroring clientOnce below) if none was set explicitly.
+// Submit may be called from multiple goroutines on the same Runtime, so this
+// must not race with itself.
+func (r *Runtime) endpointSelector() EndpointSelector {
+	r.selectorOnce.Do(func() {
+		if r.EndpointSelector == nil {
+			r.EndpointSelector = NewRoundRobinSelector(r.Hosts)
+		}
+	})
+	return r.EndpointSelector
+}
+
 func transportOrDefault(left, right http.RoundTripper) http.RoundTripper {
 	if left == nil {
 		return right
@@ -351,7 +463,18 @@ func (r *Runtime) Submit(operation *runtime.ClientOperation) (interface{}, error
 		return nil, err
 	}
 	req.URL.Scheme = r.pickScheme(operation.Schemes)
-	req.URL.Host = r.Host
+	if r.unixSocketScheme != "" {
+		req.URL.Scheme = r.unixSocketScheme
+	}
+
+	selectedHost := r.Host
+	if len(r.Hosts) > 0 {
+		selectedHost, err = r.endpointSelector().Select()
+		if err != nil {
+			return nil, err
+		}
+	}
+	req.URL.Host = selectedHost
 
 	r.clientOnce.Do(func() {
 		r.client = &http.Client{
@@ -391,8 +514,23 @@ func (r *Runtime) Submit(operation *runtime.ClientOperation) (interface{}, error
 	if client == nil {
 		client = r.client
 	}
-	req = req.WithContext(ctx)
-	res, err := client.Do(req) // make requests, by default follows 10 redirects before failing
+	req = req.WithContext(contextWithOperationID(ctx, operation.ID))
+
+	policy, allowNonIdempotent := r.RetryPolicy, false
+	if override, ok := retryOverrideFromContext(ctx); ok {
+		if override.Policy != nil {
+			policy = *override.Policy
+		}
+		allowNonIdempotent = override.AllowNonIdempotent
+	}
+	res, err := doWithRetry(ctx, client, req, policy, allowNonIdempotent) // make requests, by default follows 10 redirects before failing
+	if r.EndpointSelector != nil {
+		status := 0
+		if res != nil {
+			status = res.StatusCode
+		}
+		r.EndpointSelector.Report(selectedHost, status, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,84 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// TestEndpointSelector_ConcurrentInitIsRaceFree reproduces the data race
+// reported against the lazy `if r.EndpointSelector == nil { ... }` init in
+// Submit: many goroutines calling endpointSelector() concurrently on a fresh
+// Runtime must all observe the same selector instance, with no torn read.
+// Run with -race to catch a regression.
+func TestEndpointSelector_ConcurrentInitIsRaceFree(t *testing.T) {
+	rt := New("example.com", "/", []string{"http"})
+	rt.Hosts = []string{"a", "b", "c"}
+
+	const goroutines = 50
+	selectors := make([]EndpointSelector, goroutines)
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			selectors[i] = rt.endpointSelector()
+		}()
+	}
+	wg.Wait()
+
+	first := selectors[0]
+	for i, s := range selectors {
+		if s != first {
+			t.Fatalf("goroutine %d observed a different EndpointSelector instance than goroutine 0", i)
+		}
+	}
+}
+
+func TestNewWithClient_InstallsUnixSocketTransportWhenClientHasNone(t *testing.T) {
+	client := &http.Client{}
+	rt, err := NewWithClient("unix:///var/run/app.sock", "/", []string{"http"}, client)
+	if err != nil {
+		t.Fatalf("NewWithClient: %v", err)
+	}
+	if client.Transport == nil {
+		t.Fatal("client.Transport is nil; the unix socket dialer was not installed")
+	}
+	if rt.Host != "unix" {
+		t.Fatalf("rt.Host = %q, want %q", rt.Host, "unix")
+	}
+}
+
+func TestNewWithClient_ErrorsOnUnixSocketWithCustomTransport(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	if _, err := NewWithClient("unix:///var/run/app.sock", "/", []string{"http"}, client); err == nil {
+		t.Fatal("want an error when a unix socket host is combined with a client that already has a Transport")
+	}
+}
+
+func TestNewWithClient_NonUnixHostUnaffected(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	rt, err := NewWithClient("example.com", "/", []string{"http"}, client)
+	if err != nil {
+		t.Fatalf("NewWithClient: %v", err)
+	}
+	if rt.Host != "example.com" {
+		t.Fatalf("rt.Host = %q, want example.com", rt.Host)
+	}
+}
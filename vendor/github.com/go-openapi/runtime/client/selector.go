@@ -0,0 +1,152 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// EndpointSelector picks which of a Runtime's Hosts to use for a given
+// Submit call, and is told the outcome afterwards so it can track endpoint
+// health across calls.
+type EndpointSelector interface {
+	// Select returns the host:port to use for the next request.
+	Select() (string, error)
+
+	// Report is called once per Submit with the endpoint Select returned,
+	// the HTTP status code received (0 if err is non-nil), and any transport
+	// error.
+	Report(endpoint string, statusCode int, err error)
+}
+
+// roundRobinSelector cycles through hosts in order.
+type roundRobinSelector struct {
+	hosts []string
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewRoundRobinSelector returns an EndpointSelector that cycles through
+// hosts in order, wrapping around.
+func NewRoundRobinSelector(hosts []string) EndpointSelector {
+	return &roundRobinSelector{hosts: hosts}
+}
+
+func (s *roundRobinSelector) Select() (string, error) {
+	if len(s.hosts) == 0 {
+		return "", fmt.Errorf("endpoint selector: no hosts configured")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	host := s.hosts[s.next%len(s.hosts)]
+	s.next++
+	return host, nil
+}
+
+func (s *roundRobinSelector) Report(string, int, error) {}
+
+// randomSelector picks a uniformly random host on each call.
+type randomSelector struct {
+	hosts []string
+}
+
+// NewRandomSelector returns an EndpointSelector that picks a uniformly
+// random host from hosts on each call.
+func NewRandomSelector(hosts []string) EndpointSelector {
+	return &randomSelector{hosts: hosts}
+}
+
+func (s *randomSelector) Select() (string, error) {
+	if len(s.hosts) == 0 {
+		return "", fmt.Errorf("endpoint selector: no hosts configured")
+	}
+	return s.hosts[rand.Intn(len(s.hosts))], nil
+}
+
+func (s *randomSelector) Report(string, int, error) {}
+
+// healthTrackingSelector round-robins over hosts, ejecting any host that
+// returns ConsecutiveFailures worth of 5xx responses (or transport errors)
+// in a row for CoolOff before it is offered again.
+type healthTrackingSelector struct {
+	hosts               []string
+	consecutiveFailures int
+	coolOff             time.Duration
+
+	mu        sync.Mutex
+	next      int
+	failures  map[string]int
+	ejectedAt map[string]time.Time
+}
+
+// NewHealthTrackingSelector returns an EndpointSelector that round-robins
+// over healthy hosts, ejecting one after consecutiveFailures in a row (5xx
+// or transport error) for coolOff before it is considered again.
+func NewHealthTrackingSelector(hosts []string, consecutiveFailures int, coolOff time.Duration) EndpointSelector {
+	return &healthTrackingSelector{
+		hosts:               hosts,
+		consecutiveFailures: consecutiveFailures,
+		coolOff:             coolOff,
+		failures:            make(map[string]int),
+		ejectedAt:           make(map[string]time.Time),
+	}
+}
+
+func (s *healthTrackingSelector) Select() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.hosts) == 0 {
+		return "", fmt.Errorf("endpoint selector: no hosts configured")
+	}
+
+	for i := 0; i < len(s.hosts); i++ {
+		host := s.hosts[s.next%len(s.hosts)]
+		s.next++
+		if ejectedAt, ejected := s.ejectedAt[host]; !ejected || time.Since(ejectedAt) >= s.coolOff {
+			delete(s.ejectedAt, host)
+			return host, nil
+		}
+	}
+
+	// every host is in its cool-off window; serve the least-recently ejected one
+	var oldest string
+	var oldestAt time.Time
+	for host, at := range s.ejectedAt {
+		if oldest == "" || at.Before(oldestAt) {
+			oldest, oldestAt = host, at
+		}
+	}
+	return oldest, nil
+}
+
+func (s *healthTrackingSelector) Report(endpoint string, statusCode int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil && statusCode < 500 {
+		s.failures[endpoint] = 0
+		return
+	}
+
+	s.failures[endpoint]++
+	if s.failures[endpoint] >= s.consecutiveFailures {
+		s.ejectedAt[endpoint] = time.Now()
+	}
+}
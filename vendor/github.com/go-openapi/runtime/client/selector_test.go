@@ -0,0 +1,129 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRoundRobinSelector_CyclesInOrder(t *testing.T) {
+	s := NewRoundRobinSelector([]string{"a", "b", "c"})
+	var got []string
+	for i := 0; i < 7; i++ {
+		host, err := s.Select()
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		got = append(got, host)
+	}
+	want := []string{"a", "b", "c", "a", "b", "c", "a"}
+	for i, host := range want {
+		if got[i] != host {
+			t.Fatalf("Select sequence = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRoundRobinSelector_NoHosts(t *testing.T) {
+	s := NewRoundRobinSelector(nil)
+	if _, err := s.Select(); err == nil {
+		t.Fatal("want an error when no hosts are configured")
+	}
+}
+
+func TestRandomSelector_OnlyReturnsConfiguredHosts(t *testing.T) {
+	hosts := []string{"a", "b", "c"}
+	s := NewRandomSelector(hosts)
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		host, err := s.Select()
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		found := false
+		for _, h := range hosts {
+			if h == host {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("Select returned %q, not one of %v", host, hosts)
+		}
+		seen[host] = true
+	}
+}
+
+func TestHealthTrackingSelector_EjectsAfterConsecutiveFailures(t *testing.T) {
+	s := NewHealthTrackingSelector([]string{"a", "b"}, 2, time.Hour).(*healthTrackingSelector)
+
+	s.Report("a", 500, nil)
+	s.Report("a", 500, nil)
+
+	// "a" is now ejected; every Select should return "b" instead, since "a"
+	// won't be reconsidered until its hour-long cool-off elapses.
+	for i := 0; i < 4; i++ {
+		host, err := s.Select()
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if host != "b" {
+			t.Fatalf("Select() = %q, want b (a should be ejected)", host)
+		}
+	}
+}
+
+func TestHealthTrackingSelector_ReportSuccessResetsFailureCount(t *testing.T) {
+	s := NewHealthTrackingSelector([]string{"a", "b"}, 2, time.Hour).(*healthTrackingSelector)
+
+	s.Report("a", 500, nil)
+	s.Report("a", 200, nil) // reset
+	s.Report("a", 500, nil)
+
+	// Only one consecutive failure recorded since the reset, so "a" has not
+	// hit the threshold of 2 and should still be selectable.
+	seenA := false
+	for i := 0; i < 4; i++ {
+		host, err := s.Select()
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if host == "a" {
+			seenA = true
+		}
+	}
+	if !seenA {
+		t.Fatal("host a was ejected despite only 1 consecutive failure since its last success")
+	}
+}
+
+func TestHealthTrackingSelector_AllEjectedServesOldestEjection(t *testing.T) {
+	s := NewHealthTrackingSelector([]string{"a", "b"}, 1, time.Hour).(*healthTrackingSelector)
+
+	s.Report("a", http.StatusInternalServerError, nil)
+	time.Sleep(time.Millisecond)
+	s.Report("b", http.StatusInternalServerError, nil)
+
+	// Both hosts are ejected; Select must still return a host (the
+	// least-recently ejected one) rather than erroring.
+	host, err := s.Select()
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if host != "a" {
+		t.Fatalf("Select() = %q, want a (ejected first, so least-recently ejected)", host)
+	}
+}
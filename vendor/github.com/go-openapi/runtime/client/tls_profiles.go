@@ -0,0 +1,158 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+var tlsVersionsByName = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+var tlsCurvesByName = map[string]tls.CurveID{
+	"P256":   tls.CurveP256,
+	"P384":   tls.CurveP384,
+	"P521":   tls.CurveP521,
+	"X25519": tls.X25519,
+}
+
+func tlsCipherSuitesByName() map[string]uint16 {
+	suites := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		suites[s.Name] = s.ID
+	}
+	return suites
+}
+
+// tlsProfile is a curated, named bundle of protocol-level TLS settings.
+type tlsProfile struct {
+	minVersion, maxVersion uint16
+	cipherSuites           []string
+	curvePreferences       []string
+}
+
+var tlsProfiles = map[string]tlsProfile{
+	// modern: TLS 1.3 only, which has no configurable cipher suites.
+	"modern": {
+		minVersion: tls.VersionTLS13,
+		maxVersion: tls.VersionTLS13,
+	},
+	// intermediate: TLS 1.2+ restricted to forward-secret AEAD ciphers,
+	// mirroring the Mozilla "intermediate" compatibility guidance.
+	"intermediate": {
+		minVersion: tls.VersionTLS12,
+		cipherSuites: []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+			"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305",
+		},
+		curvePreferences: []string{"X25519", "P256", "P384"},
+	},
+	// fips: TLS 1.2+ restricted to FIPS 140-2 approved ciphers and curves.
+	"fips": {
+		minVersion: tls.VersionTLS12,
+		cipherSuites: []string{
+			"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		},
+		curvePreferences: []string{"P256", "P384", "P521"},
+	},
+}
+
+// applyTLSProtocolOptions translates the protocol-level fields of opts (plus
+// any named Profile) onto cfg, returning a descriptive error for unknown
+// version, cipher suite, or curve names.
+func applyTLSProtocolOptions(cfg *tls.Config, opts TLSClientOptions) error {
+	minVersion, maxVersion := opts.MinVersion, opts.MaxVersion
+	cipherSuites, curvePreferences := opts.CipherSuites, opts.CurvePreferences
+
+	if opts.Profile != "" {
+		profile, ok := tlsProfiles[opts.Profile]
+		if !ok {
+			return fmt.Errorf("tls profile: unknown profile %q", opts.Profile)
+		}
+		if minVersion == "" && profile.minVersion != 0 {
+			cfg.MinVersion = profile.minVersion
+		}
+		if maxVersion == "" && profile.maxVersion != 0 {
+			cfg.MaxVersion = profile.maxVersion
+		}
+		if len(cipherSuites) == 0 {
+			cipherSuites = profile.cipherSuites
+		}
+		if len(curvePreferences) == 0 {
+			curvePreferences = profile.curvePreferences
+		}
+	}
+
+	if minVersion != "" {
+		v, ok := tlsVersionsByName[minVersion]
+		if !ok {
+			return fmt.Errorf("tls min version: unknown version %q", minVersion)
+		}
+		cfg.MinVersion = v
+	}
+	if maxVersion != "" {
+		v, ok := tlsVersionsByName[maxVersion]
+		if !ok {
+			return fmt.Errorf("tls max version: unknown version %q", maxVersion)
+		}
+		cfg.MaxVersion = v
+	}
+
+	if len(cipherSuites) > 0 {
+		byName := tlsCipherSuitesByName()
+		ids := make([]uint16, 0, len(cipherSuites))
+		for _, name := range cipherSuites {
+			id, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("tls cipher suite: unknown cipher suite %q", name)
+			}
+			ids = append(ids, id)
+		}
+		cfg.CipherSuites = ids
+	}
+
+	if len(curvePreferences) > 0 {
+		curves := make([]tls.CurveID, 0, len(curvePreferences))
+		for _, name := range curvePreferences {
+			curve, ok := tlsCurvesByName[name]
+			if !ok {
+				return fmt.Errorf("tls curve preference: unknown curve %q", name)
+			}
+			curves = append(curves, curve)
+		}
+		cfg.CurvePreferences = curves
+	}
+
+	if len(opts.NextProtos) > 0 {
+		cfg.NextProtos = opts.NextProtos
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestApplyTLSProtocolOptions_Profile(t *testing.T) {
+	cfg := &tls.Config{}
+	if err := applyTLSProtocolOptions(cfg, TLSClientOptions{Profile: "modern"}); err != nil {
+		t.Fatalf("applyTLSProtocolOptions: %v", err)
+	}
+	if cfg.MinVersion != tls.VersionTLS13 || cfg.MaxVersion != tls.VersionTLS13 {
+		t.Fatalf("modern profile min/max = %d/%d, want TLS 1.3 only", cfg.MinVersion, cfg.MaxVersion)
+	}
+}
+
+func TestApplyTLSProtocolOptions_ExplicitFieldsOverrideProfile(t *testing.T) {
+	cfg := &tls.Config{}
+	opts := TLSClientOptions{Profile: "modern", MinVersion: "TLS12"}
+	if err := applyTLSProtocolOptions(cfg, opts); err != nil {
+		t.Fatalf("applyTLSProtocolOptions: %v", err)
+	}
+	if cfg.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("MinVersion = %d, want explicit TLS12 to override the profile's TLS13", cfg.MinVersion)
+	}
+}
+
+func TestApplyTLSProtocolOptions_UnknownProfile(t *testing.T) {
+	err := applyTLSProtocolOptions(&tls.Config{}, TLSClientOptions{Profile: "nonexistent"})
+	if err == nil {
+		t.Fatal("want an error for an unknown profile name")
+	}
+}
+
+func TestApplyTLSProtocolOptions_UnknownVersionCipherCurve(t *testing.T) {
+	cases := []TLSClientOptions{
+		{MinVersion: "TLS99"},
+		{MaxVersion: "TLS99"},
+		{CipherSuites: []string{"NOT_A_REAL_CIPHER"}},
+		{CurvePreferences: []string{"NOT_A_REAL_CURVE"}},
+	}
+	for _, opts := range cases {
+		if err := applyTLSProtocolOptions(&tls.Config{}, opts); err == nil {
+			t.Errorf("opts = %+v: want an error, got nil", opts)
+		}
+	}
+}
+
+func TestApplyTLSProtocolOptions_ValidCipherAndCurve(t *testing.T) {
+	cfg := &tls.Config{}
+	opts := TLSClientOptions{
+		MinVersion:       "TLS12",
+		CipherSuites:     []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		CurvePreferences: []string{"X25519", "P256"},
+		NextProtos:       []string{"h2"},
+	}
+	if err := applyTLSProtocolOptions(cfg, opts); err != nil {
+		t.Fatalf("applyTLSProtocolOptions: %v", err)
+	}
+	if len(cfg.CipherSuites) != 1 || cfg.CipherSuites[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("CipherSuites = %v, want [TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256]", cfg.CipherSuites)
+	}
+	if len(cfg.CurvePreferences) != 2 || cfg.CurvePreferences[0] != tls.X25519 || cfg.CurvePreferences[1] != tls.CurveP256 {
+		t.Fatalf("CurvePreferences = %v, want [X25519 P256]", cfg.CurvePreferences)
+	}
+	if len(cfg.NextProtos) != 1 || cfg.NextProtos[0] != "h2" {
+		t.Fatalf("NextProtos = %v, want [h2]", cfg.NextProtos)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloadingTransport is an http.RoundTripper that periodically re-reads the
+// client certificate and/or CA certificate backing its tls.Config from disk,
+// so that long-lived processes pick up rotated material without a restart.
+type reloadingTransport struct {
+	*http.Transport
+
+	certReloader *certReloader
+	caReloader   *caReloader
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newReloadingTransport builds the background reloaders requested by opts and
+// wires them into cfg before handing both to an http.Transport.
+func newReloadingTransport(opts TLSClientOptions, cfg *tls.Config) (*reloadingTransport, error) {
+	rt := &reloadingTransport{done: make(chan struct{})}
+
+	if opts.CertRefresh > 0 && opts.Certificate != "" && opts.Key != "" {
+		reloader, err := newCertReloader(opts)
+		if err != nil {
+			return nil, err
+		}
+		rt.certReloader = reloader
+		cfg.Certificates = nil
+		cfg.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	if opts.CARefresh > 0 && opts.CA != "" && opts.LoadedCA == nil {
+		reloader, err := newCAReloader(opts.CA)
+		if err != nil {
+			return nil, err
+		}
+		rt.caReloader = reloader
+	}
+
+	transport := &http.Transport{TLSClientConfig: cfg}
+	if rt.caReloader != nil {
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialCfg := cfg.Clone()
+			dialCfg.RootCAs = rt.caReloader.Pool()
+			dialer := tls.Dialer{Config: dialCfg}
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+	rt.Transport = transport
+
+	go rt.run(opts)
+
+	return rt, nil
+}
+
+// run polls the configured reloaders until Stop is called.
+func (rt *reloadingTransport) run(opts TLSClientOptions) {
+	interval := opts.CertRefresh
+	if opts.CARefresh > 0 && (interval == 0 || opts.CARefresh < interval) {
+		interval = opts.CARefresh
+	}
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rt.done:
+			return
+		case <-ticker.C:
+			if rt.certReloader != nil {
+				_ = rt.certReloader.maybeReload()
+			}
+			if rt.caReloader != nil {
+				_ = rt.caReloader.maybeReload()
+			}
+		}
+	}
+}
+
+// Stop halts the background reload goroutine. It is safe to call more than
+// once and safe to call even when no refresh interval was configured.
+func (rt *reloadingTransport) Stop() {
+	rt.stopOnce.Do(func() {
+		close(rt.done)
+	})
+}
+
+// fileState captures enough information about a file to cheaply detect
+// whether it has changed since it was last parsed.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func statState(path string) (fileState, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// certReloader keeps a tls.Certificate parsed from a cert/key pair on disk,
+// refreshing it only when the certificate file changes. Only the certificate
+// file is stat'ed; a change to the key file alone is not detected.
+type certReloader struct {
+	certPath, keyPath string
+	opts              TLSClientOptions
+
+	mu    sync.RWMutex
+	state fileState
+	cert  tls.Certificate
+}
+
+func newCertReloader(opts TLSClientOptions) (*certReloader, error) {
+	r := &certReloader{certPath: opts.Certificate, keyPath: opts.Key, opts: opts}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	cert, err := loadX509KeyPair(r.opts)
+	if err != nil {
+		return err
+	}
+	state, err := statState(r.certPath)
+	if err != nil {
+		return fmt.Errorf("tls client cert: %v", err)
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.state = state
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *certReloader) maybeReload() error {
+	state, err := statState(r.certPath)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	changed := state != r.state
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return r.reload()
+}
+
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cert := r.cert
+	return &cert, nil
+}
+
+// caReloader keeps an x509.CertPool parsed from a CA file on disk,
+// refreshing it only when the underlying file changes.
+type caReloader struct {
+	path string
+
+	mu    sync.RWMutex
+	state fileState
+	pool  *x509.CertPool
+}
+
+func newCAReloader(path string) (*caReloader, error) {
+	r := &caReloader{path: path}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *caReloader) reload() error {
+	caCert, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("tls client ca: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caCert)
+
+	state, err := statState(r.path)
+	if err != nil {
+		return fmt.Errorf("tls client ca: %v", err)
+	}
+
+	r.mu.Lock()
+	r.pool = pool
+	r.state = state
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *caReloader) maybeReload() error {
+	state, err := statState(r.path)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	changed := state != r.state
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	return r.reload()
+}
+
+func (r *caReloader) Pool() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
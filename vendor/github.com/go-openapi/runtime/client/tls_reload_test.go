@@ -0,0 +1,131 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertReloader_PicksUpChangedCert(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+
+	certPEM1, keyPEM1, _ := generateTestKeyAndCertPEM(t)
+	writeFile(t, certPath, certPEM1)
+	writeFile(t, keyPath, keyPEM1)
+
+	r, err := newCertReloader(TLSClientOptions{Certificate: certPath, Key: keyPath})
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+	first, err := r.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetClientCertificate: %v", err)
+	}
+
+	// Rewrite with different cert/key material. Sleep past filesystem mtime
+	// granularity so statState sees a genuinely newer ModTime.
+	time.Sleep(10 * time.Millisecond)
+	certPEM2, keyPEM2, _ := generateTestKeyAndCertPEM(t)
+	writeFile(t, certPath, certPEM2)
+	writeFile(t, keyPath, keyPEM2)
+
+	if err := r.maybeReload(); err != nil {
+		t.Fatalf("maybeReload: %v", err)
+	}
+	second, err := r.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetClientCertificate: %v", err)
+	}
+
+	if string(first.Certificate[0]) == string(second.Certificate[0]) {
+		t.Fatal("GetClientCertificate returned the same certificate bytes after the file changed")
+	}
+}
+
+func TestCertReloader_NoReloadWithoutChange(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	certPEM, keyPEM, _ := generateTestKeyAndCertPEM(t)
+	writeFile(t, certPath, certPEM)
+	writeFile(t, keyPath, keyPEM)
+
+	r, err := newCertReloader(TLSClientOptions{Certificate: certPath, Key: keyPath})
+	if err != nil {
+		t.Fatalf("newCertReloader: %v", err)
+	}
+	before, _ := r.GetClientCertificate(nil)
+
+	if err := r.maybeReload(); err != nil {
+		t.Fatalf("maybeReload: %v", err)
+	}
+	after, _ := r.GetClientCertificate(nil)
+
+	if string(before.Certificate[0]) != string(after.Certificate[0]) {
+		t.Fatal("maybeReload reparsed the certificate even though the file did not change")
+	}
+}
+
+func TestCAReloader_PicksUpChangedCA(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+
+	ca1 := generateTestCertPEM(t)
+	writeFile(t, caPath, ca1)
+
+	r, err := newCAReloader(caPath)
+	if err != nil {
+		t.Fatalf("newCAReloader: %v", err)
+	}
+	firstPool := r.Pool()
+	if len(firstPool.Subjects()) != 1 { //nolint:staticcheck // Subjects is deprecated but fine for a test assertion
+		t.Fatalf("initial pool has %d subjects, want 1", len(firstPool.Subjects())) //nolint:staticcheck
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	ca2 := generateTestCertPEM(t)
+	writeFile(t, caPath, ca2)
+
+	if err := r.maybeReload(); err != nil {
+		t.Fatalf("maybeReload: %v", err)
+	}
+	secondPool := r.Pool()
+	if secondPool == firstPool {
+		t.Fatal("Pool() returned the same *x509.CertPool instance after the CA file changed")
+	}
+}
+
+func TestReloadingTransport_StopIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	certPEM, keyPEM, _ := generateTestKeyAndCertPEM(t)
+	writeFile(t, certPath, certPEM)
+	writeFile(t, keyPath, keyPEM)
+
+	opts := TLSClientOptions{Certificate: certPath, Key: keyPath, CertRefresh: time.Millisecond}
+	rt, err := newReloadingTransport(opts, &tls.Config{})
+	if err != nil {
+		t.Fatalf("newReloadingTransport: %v", err)
+	}
+
+	rt.Stop()
+	rt.Stop() // must not panic (close of closed channel)
+}
@@ -0,0 +1,48 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// unixSocketPath recognizes a Host of the form "unix:///path/to.sock" or
+// "http+unix:///path/to.sock" and returns the filesystem path to dial.
+func unixSocketPath(host string) (string, bool) {
+	switch {
+	case strings.HasPrefix(host, "http+unix://"):
+		return strings.TrimPrefix(host, "http+unix://"), true
+	case strings.HasPrefix(host, "unix://"):
+		return strings.TrimPrefix(host, "unix://"), true
+	default:
+		return "", false
+	}
+}
+
+// unixSocketTransport builds an http.Transport that dials socketPath over a
+// unix domain socket for every request, ignoring the network/address the
+// caller asks for: a Runtime configured this way only ever talks to that one
+// socket.
+func unixSocketTransport(socketPath string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+}
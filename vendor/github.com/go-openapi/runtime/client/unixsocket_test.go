@@ -0,0 +1,84 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnixSocketPath(t *testing.T) {
+	cases := []struct {
+		host     string
+		wantPath string
+		wantOK   bool
+	}{
+		{"unix:///var/run/app.sock", "/var/run/app.sock", true},
+		{"http+unix:///var/run/app.sock", "/var/run/app.sock", true},
+		{"http://example.com", "", false},
+		{"example.com:8080", "", false},
+	}
+	for _, c := range cases {
+		path, ok := unixSocketPath(c.host)
+		if ok != c.wantOK || path != c.wantPath {
+			t.Errorf("unixSocketPath(%q) = (%q, %v), want (%q, %v)", c.host, path, ok, c.wantPath, c.wantOK)
+		}
+	}
+}
+
+func TestUnixSocketTransport_DialsTheSocket(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "app.sock")
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	transport := unixSocketTransport(socketPath)
+	defer transport.CloseIdleConnections()
+
+	// The transport must dial socketPath regardless of the host/port in the
+	// request URL.
+	req, _ := http.NewRequest(http.MethodGet, "http://ignored-host:9999/", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestUnixSocketTransport_MissingSocket(t *testing.T) {
+	transport := unixSocketTransport(filepath.Join(t.TempDir(), "does-not-exist.sock"))
+	req, _ := http.NewRequest(http.MethodGet, "http://ignored/", nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Fatal("want an error when the socket does not exist")
+	}
+}